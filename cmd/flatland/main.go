@@ -1,11 +1,178 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
 	"github.com/matjam/flatland/internal/cache"
+	"github.com/matjam/flatland/internal/server"
 )
 
 func main() {
-	objectCache := cache.New()
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "query":
+		runQuery(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: flatland <import|query|serve> [flags]")
+}
+
+// dataFlags are the flags shared by every subcommand that needs a loaded
+// DataSetCache: where the dataset comes from, how to parse it, and where
+// to persist the imported result so later runs can skip re-importing it.
+type dataFlags struct {
+	source        *string
+	cacheFile     *string
+	delimiter     *string
+	comment       *string
+	noHeader      *bool
+	noConvertNums *bool
+	chunkSize     *int
+	progress      *bool
+}
+
+func addDataFlags(fs *flag.FlagSet) *dataFlags {
+	return &dataFlags{
+		source:        fs.String("source", "data/5m_sales_records.csv", "dataset to import: a local path, or a file://, http(s):// or s3://bucket/key URL"),
+		cacheFile:     fs.String("cache", "", "if set and the file exists, Load it instead of importing; otherwise Save the freshly imported cache there"),
+		delimiter:     fs.String("delimiter", ",", "CSV field delimiter"),
+		comment:       fs.String("comment", "", "if set, lines starting with this rune are skipped as comments"),
+		noHeader:      fs.Bool("no-header", false, "treat the first record as data instead of a header row"),
+		noConvertNums: fs.Bool("no-convert-numbers", false, "keep every column as strings instead of inferring integer/float types"),
+		chunkSize:     fs.Int("chunk-size", 0, "rows processed per progress update (0 uses the library default)"),
+		progress:      fs.Bool("progress", false, "print import progress to stderr"),
+	}
+}
+
+// load resolves d into a DataSetCache, either by loading a previously
+// Save'd cache file or by importing from source with the parsed
+// ImportOptions, reporting progress to stderr when requested.
+func (d *dataFlags) load() (*cache.DataSetCache, error) {
+	if *d.cacheFile != "" {
+		if _, err := os.Stat(*d.cacheFile); err == nil {
+			return cache.Load(*d.cacheFile)
+		}
+	}
+
+	opts := cache.DefaultImportOptions()
+	if *d.delimiter != "" {
+		opts.Delimiter = []rune(*d.delimiter)[0]
+	}
+	if *d.comment != "" {
+		opts.Comment = []rune(*d.comment)[0]
+	}
+	opts.HasHeader = !*d.noHeader
+	opts.ConvertNumbers = !*d.noConvertNums
+	if *d.chunkSize > 0 {
+		opts.ChunkSize = *d.chunkSize
+	}
+
+	c := cache.New()
+	ctx := context.Background()
+
+	if *d.progress {
+		progress := make(chan cache.Progress)
+		opts.Progress = progress
+
+		done := make(chan error, 1)
+		go func() {
+			done <- c.ImportContext(ctx, *d.source, opts)
+			close(progress)
+		}()
+
+		for p := range progress {
+			if p.RowsEstimate > 0 {
+				fmt.Fprintf(os.Stderr, "\rimported %d of ~%d rows (%d bytes)", p.RowsProcessed, p.RowsEstimate, p.BytesRead)
+			} else {
+				fmt.Fprintf(os.Stderr, "\rimported %d rows (%d bytes)", p.RowsProcessed, p.BytesRead)
+			}
+		}
+		fmt.Fprintln(os.Stderr)
+
+		if err := <-done; err != nil {
+			return nil, err
+		}
+	} else if err := c.ImportContext(ctx, *d.source, opts); err != nil {
+		return nil, err
+	}
+
+	if *d.cacheFile != "" {
+		if err := c.Save(*d.cacheFile); err != nil {
+			return nil, fmt.Errorf("could not save cache to %v: %w", *d.cacheFile, err)
+		}
+	}
+
+	return c, nil
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	d := addDataFlags(fs)
+	_ = fs.Parse(args)
+
+	if _, err := d.load(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	d := addDataFlags(fs)
+	sql := fs.String("sql", "", "SQL-like SELECT statement to run")
+	_ = fs.Parse(args)
+
+	if *sql == "" {
+		log.Fatal("query: -sql is required")
+	}
+
+	c, err := d.load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := c.Query(*sql)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(result.Columns, "\t"))
+	for _, row := range result.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	d := addDataFlags(fs)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	_ = fs.Parse(args)
+
+	c, err := d.load()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	objectCache.Import("data/5m_sales_records.csv")
+	e := server.New(c)
+	log.Fatal(e.Start(*addr))
 }
@@ -1,12 +1,7 @@
 package cache
 
 import (
-	"bufio"
-	"encoding/csv"
 	"fmt"
-	"io"
-	"log"
-	"os"
 	"strconv"
 )
 
@@ -32,102 +27,181 @@ func fieldTypeString(t FieldType) string {
 	return "FIELD_TYPE_UNKNOWN"
 }
 
+// Column is the column-major, natively-typed backing store for a single
+// field. Only the slice matching Type is populated; the others are nil.
+// Valid is a parallel bitmap the same length as the column: Valid[i] is
+// false where row i's value couldn't be parsed as Type (e.g. an empty
+// cell in an otherwise-numeric column), in which case the corresponding
+// Ints/Floats/Strings entry is the zero value.
+type Column struct {
+	Type    FieldType
+	Ints    []int64
+	Floats  []float64
+	Strings []string
+	Valid   []bool
+}
+
+func newColumn(t FieldType, capacity int) *Column {
+	col := &Column{Type: t, Valid: make([]bool, 0, capacity)}
+
+	switch t {
+	case FieldTypeInteger:
+		col.Ints = make([]int64, 0, capacity)
+	case FieldTypeFloat:
+		col.Floats = make([]float64, 0, capacity)
+	default:
+		col.Strings = make([]string, 0, capacity)
+	}
+
+	return col
+}
+
+// append parses raw into the column's type and appends it, marking the
+// row invalid (and storing the type's zero value) if it doesn't parse.
+func (col *Column) append(raw string) {
+	switch col.Type {
+	case FieldTypeInteger:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			col.Ints = append(col.Ints, 0)
+			col.Valid = append(col.Valid, false)
+			return
+		}
+		col.Ints = append(col.Ints, v)
+		col.Valid = append(col.Valid, true)
+	case FieldTypeFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			col.Floats = append(col.Floats, 0)
+			col.Valid = append(col.Valid, false)
+			return
+		}
+		col.Floats = append(col.Floats, v)
+		col.Valid = append(col.Valid, true)
+	default:
+		col.Strings = append(col.Strings, raw)
+		col.Valid = append(col.Valid, true)
+	}
+}
+
+// numericAt returns row i's value as a float64 read directly from the
+// typed Ints/Floats backing slice, skipping the stringAt+ParseFloat round
+// trip. ok is false for a non-numeric column, or an invalid (null) row.
+func (col *Column) numericAt(i int) (float64, bool) {
+	if i >= len(col.Valid) || !col.Valid[i] {
+		return 0, false
+	}
+
+	switch col.Type {
+	case FieldTypeInteger:
+		return float64(col.Ints[i]), true
+	case FieldTypeFloat:
+		return col.Floats[i], true
+	}
+
+	return 0, false
+}
+
+// widen converts col into a new Column of newType, a wider type in the
+// integer -> float -> string promotion order. Each existing value is
+// rendered back to its original string form and re-parsed as newType, so
+// already-imported chunks can be promoted in place when a later chunk's
+// data forces the column wider, instead of re-reading the source file.
+func (col *Column) widen(newType FieldType) *Column {
+	widened := newColumn(newType, len(col.Valid))
+	for i := range col.Valid {
+		widened.append(col.stringAt(i))
+	}
+
+	return widened
+}
+
+// stringAt renders row i of the column back to its original string form,
+// regardless of the underlying storage type. It returns "" for a null row.
+func (col *Column) stringAt(i int) string {
+	if i >= len(col.Valid) || !col.Valid[i] {
+		return ""
+	}
+
+	switch col.Type {
+	case FieldTypeInteger:
+		return strconv.FormatInt(col.Ints[i], 10)
+	case FieldTypeFloat:
+		return strconv.FormatFloat(col.Floats[i], 'f', -1, 64)
+	default:
+		return col.Strings[i]
+	}
+}
 
 type DataSetCache struct {
 	FieldNames []string
 	FieldTypes []FieldType
-	FieldData [][]string
+	Columns    []*Column
+	RowCount   int
 }
 
 func New() *DataSetCache {
-	var c DataSetCache
-
-	// This allocates the top level slice; not the inner slices. They are allocated as we read in the data.
-	c.FieldData = make([][]string, 0, 1000)
-
-	return &c
+	return &DataSetCache{}
 }
 
-func (c *DataSetCache) Import(URI string) error {
-	log.Println("importing", URI)
+// StringAt returns the string representation of the value at [row][col],
+// regardless of the column's underlying storage type. This is the
+// string-view fallback for callers (such as the query engine) that want
+// to treat every column uniformly rather than type-switching themselves.
+func (c *DataSetCache) StringAt(row, col int) string {
+	return c.Columns[col].stringAt(row)
+}
 
-	file, err := os.Open(URI)
+// IntColumn returns the typed backing slice and validity bitmap for an
+// integer column. It errors if name isn't a FieldTypeInteger column.
+func (c *DataSetCache) IntColumn(name string) ([]int64, []bool, error) {
+	col, err := c.columnByName(name)
 	if err != nil {
-		return fmt.Errorf("could not open file %v: %w", URI, err)
+		return nil, nil, err
+	}
+	if col.Type != FieldTypeInteger {
+		return nil, nil, fmt.Errorf("column %q is %v, not an integer column", name, fieldTypeString(col.Type))
 	}
 
-	reader := bufio.NewReader(file)
-	csvReader := csv.NewReader(reader)
+	return col.Ints, col.Valid, nil
+}
 
-	// Read the header of the file first
-	record, err := csvReader.Read()
-	if err == io.EOF {
-		return fmt.Errorf("unexpected end of file while reading CSV file header from %v", URI)
-	}
+// FloatColumn returns the typed backing slice and validity bitmap for a
+// float column. It errors if name isn't a FieldTypeFloat column.
+func (c *DataSetCache) FloatColumn(name string) ([]float64, []bool, error) {
+	col, err := c.columnByName(name)
 	if err != nil {
-		return fmt.Errorf("could not parse CSV file %v: %w", URI, err)
+		return nil, nil, err
 	}
-	c.FieldNames = record
-
-	// Initialize FieldTypes to integer first; if we see a . in the data it switches to float
-	// if we see anything else it switches to string.
-
-	c.FieldTypes = make([]FieldType, len(c.FieldNames))
-	for fieldIndex := range c.FieldNames {
-		c.FieldTypes[fieldIndex] = FieldTypeInteger
+	if col.Type != FieldTypeFloat {
+		return nil, nil, fmt.Errorf("column %q is %v, not a float column", name, fieldTypeString(col.Type))
 	}
 
-	// When we first read the dataset, we don't know what the types are initially, so we store them as Strings as
-	// we read them into memory. As we read the data we adjust the type stored in the cache for that field until
-	// we have read all the rows. If all we see are integers, then the field will be an integer field, and so on.
-
-	for {
-		record, err = csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("could not parse CSV file %v: %w", URI, err)
-		}
+	return col.Floats, col.Valid, nil
+}
 
-		fieldData := make([]string, len(record)) // allocates a slice of strings that we'll store the CSV data in
-		for column, stringValue := range record {
-			switch c.FieldTypes[column] {
-			case FieldTypeUnknown:
-				// See what this field parsed as and use that as a starting point.
-				c.FieldTypes[column] = inferProbableTypeFromString(&stringValue)
-			case FieldTypeString:
-				// if this column is already defined as a string, we just take whatever was passed in.
-				// String is the most permissive type; we can't change this column to an integer or a float because
-				// there have been values unable to be parsed as such already seen for this column.
-				//
-				// This case is left empty for readability.
-			case FieldTypeInteger:
-				// if it's an integer, it stays an integer; if it's a float or a string
-				// then we change to that.
-				c.FieldTypes[column] = inferProbableTypeFromString(&stringValue)
-			case FieldTypeFloat:
-				// If the field is a float and the current row has a value that can be parsed as an integer, the
-				// column stays a float. However, if it is a string, then we change the column to a string. So,
-				// we only care if this is parsed as a string. The other results don't change the
-				if inferProbableTypeFromString(&stringValue) == FieldTypeString {
-					c.FieldTypes[column] = FieldTypeString
-				}
-			}
-			fieldData[column] = stringValue // store the column with the string value.
-		}
-		c.FieldData = append(c.FieldData, fieldData)
+// StringColumn returns the typed backing slice and validity bitmap for a
+// string column. It errors if name isn't a FieldTypeString column.
+func (c *DataSetCache) StringColumn(name string) ([]string, []bool, error) {
+	col, err := c.columnByName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if col.Type != FieldTypeString {
+		return nil, nil, fmt.Errorf("column %q is %v, not a string column", name, fieldTypeString(col.Type))
 	}
 
-	log.Printf("finished processing CSV, %v rows processed", len(c.FieldData))
-
+	return col.Strings, col.Valid, nil
+}
 
-	log.Println("fields: ")
-	for i, v := range c.FieldNames {
-		log.Printf("   %v: %v", v, fieldTypeString(c.FieldTypes[i]))
+func (c *DataSetCache) columnByName(name string) (*Column, error) {
+	for i, n := range c.FieldNames {
+		if n == name {
+			return c.Columns[i], nil
+		}
 	}
 
-	return nil
+	return nil, fmt.Errorf("unknown column %q", name)
 }
 
 // This function tries to figure out what the passed in string could be converted to; the intent is that for
@@ -153,4 +227,4 @@ func inferProbableTypeFromString(s *string) FieldType {
 		// This parsed as an integer.
 		return FieldTypeInteger
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,96 @@
+package cache
+
+import "testing"
+
+func TestColumnAppendAndStringAt(t *testing.T) {
+	col := newColumn(FieldTypeInteger, 0)
+	col.append("42")
+	col.append("not a number")
+	col.append("7")
+
+	if got, want := col.stringAt(0), "42"; got != want {
+		t.Errorf("stringAt(0) = %q, want %q", got, want)
+	}
+	if col.Valid[1] {
+		t.Errorf("row 1 should be invalid, got valid")
+	}
+	if got, want := col.stringAt(2), "7"; got != want {
+		t.Errorf("stringAt(2) = %q, want %q", got, want)
+	}
+}
+
+func TestColumnWiden(t *testing.T) {
+	col := newColumn(FieldTypeInteger, 0)
+	col.append("1")
+	col.append("2")
+
+	widened := col.widen(FieldTypeFloat)
+	if widened.Type != FieldTypeFloat {
+		t.Fatalf("widened.Type = %v, want FieldTypeFloat", widened.Type)
+	}
+	if got, want := widened.Floats, []float64{1, 2}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("widened.Floats = %v, want %v", got, want)
+	}
+
+	asString := widened.widen(FieldTypeString)
+	if got, want := asString.Strings, []string{"1", "2"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("asString.Strings = %v, want %v", got, want)
+	}
+}
+
+func TestDataSetCacheColumnAccessors(t *testing.T) {
+	c := &DataSetCache{
+		FieldNames: []string{"Region", "Units"},
+		FieldTypes: []FieldType{FieldTypeString, FieldTypeInteger},
+		RowCount:   2,
+	}
+	c.Columns = make([]*Column, 2)
+	c.Columns[0] = newColumn(FieldTypeString, 2)
+	c.Columns[0].append("East")
+	c.Columns[0].append("West")
+	c.Columns[1] = newColumn(FieldTypeInteger, 2)
+	c.Columns[1].append("1")
+	c.Columns[1].append("2")
+
+	ints, valid, err := c.IntColumn("Units")
+	if err != nil {
+		t.Fatalf("IntColumn: %v", err)
+	}
+	if len(ints) != 2 || ints[0] != 1 || ints[1] != 2 {
+		t.Errorf("IntColumn = %v, want [1 2]", ints)
+	}
+	if !valid[0] || !valid[1] {
+		t.Errorf("expected both rows valid")
+	}
+
+	if _, _, err := c.IntColumn("Region"); err == nil {
+		t.Errorf("expected error requesting IntColumn for a string column")
+	}
+
+	if _, _, err := c.IntColumn("Missing"); err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+
+	if got, want := c.StringAt(0, 0), "East"; got != want {
+		t.Errorf("StringAt(0,0) = %q, want %q", got, want)
+	}
+}
+
+func TestInferProbableTypeFromString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FieldType
+	}{
+		{"42", FieldTypeInteger},
+		{"3.14", FieldTypeFloat},
+		{"hello", FieldTypeString},
+		{"", FieldTypeString},
+	}
+
+	for _, tc := range tests {
+		in := tc.in
+		if got := inferProbableTypeFromString(&in); got != tc.want {
+			t.Errorf("inferProbableTypeFromString(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package cache
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// defaultChunkSize is the number of rows processed between progress
+// updates and goroutine sync points when ImportOptions.ChunkSize is unset.
+const defaultChunkSize = 1000
+
+// Progress describes how far an import has gotten. It's sent on
+// ImportOptions.Progress at each chunk boundary.
+type Progress struct {
+	RowsProcessed int
+	RowsEstimate  int // 0 when the total can't be estimated (e.g. streaming from an io.Reader)
+	BytesRead     int64
+}
+
+// ImportOptions controls how Import/ImportReader parse a CSV dataset.
+type ImportOptions struct {
+	// Delimiter is the field separator. Defaults to ',' when zero.
+	Delimiter rune
+	// Comment, if set, marks lines starting with this rune as comments to
+	// be skipped, matching encoding/csv.Reader.Comment.
+	Comment rune
+	// HasHeader indicates the first record names the fields. When false,
+	// fields are named "column1", "column2", and so on, and the first
+	// record is treated as data.
+	HasHeader bool
+	// ConvertNumbers controls whether columns are type-inferred into
+	// FieldTypeInteger/FieldTypeFloat. When false, every column is kept
+	// as FieldTypeString, skipping inference entirely - useful when the
+	// caller already knows every value is a string and wants to avoid
+	// paying for the inference pass.
+	ConvertNumbers bool
+	// ChunkSize is how many rows are read and type-inferred together
+	// before a Progress update is emitted. Defaults to 1000.
+	ChunkSize int
+	// Progress, if non-nil, receives a Progress update after every chunk.
+	// The caller is responsible for draining it promptly: sends block, so
+	// a slow or absent reader stalls the import.
+	Progress chan<- Progress
+}
+
+// DefaultImportOptions returns the options Import uses: comma-delimited,
+// a header row, number inference enabled, and the default chunk size.
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{Delimiter: ',', HasHeader: true, ConvertNumbers: true, ChunkSize: defaultChunkSize}
+}
+
+// Import loads a CSV dataset from URI using DefaultImportOptions. URI may
+// be a local path, or a "file://", "http(s)://" or "s3://bucket/key" URL;
+// see Source for how the scheme is resolved.
+func (c *DataSetCache) Import(URI string) error {
+	return c.ImportContext(context.Background(), URI, DefaultImportOptions())
+}
+
+// ImportContext loads a CSV dataset from URI with explicit options and a
+// context used both to cancel the underlying fetch and to abort between
+// chunks once the data starts streaming in.
+func (c *DataSetCache) ImportContext(ctx context.Context, URI string, opts ImportOptions) error {
+	src, err := sourceForURI(URI)
+	if err != nil {
+		return err
+	}
+
+	rc, err := src.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("could not open %v: %w", URI, err)
+	}
+	defer rc.Close()
+
+	log.Println("importing", URI)
+
+	var sizeHint int64
+	if sizer, ok := src.(Sizer); ok {
+		if size, ok := sizer.Size(ctx); ok {
+			sizeHint = size
+		}
+	}
+
+	return c.importFrom(ctx, rc, opts, sizeHint)
+}
+
+// ImportReader loads a CSV dataset from an already-open reader, e.g. a
+// gzip.Reader wrapping a downloaded file, or an in-memory buffer, using
+// the given options. The total row count can't be estimated from a bare
+// reader, so Progress.RowsEstimate is always 0.
+func (c *DataSetCache) ImportReader(r io.Reader, opts ImportOptions) error {
+	rc, err := (readerSource{r: r}).Open(context.Background())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return c.importFrom(context.Background(), rc, opts, 0)
+}
+
+func (c *DataSetCache) importFrom(ctx context.Context, r io.Reader, opts ImportOptions, sizeHint int64) error {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	counting := &countingReader{r: r}
+	csvReader := csv.NewReader(counting)
+	if opts.Delimiter != 0 {
+		csvReader.Comma = opts.Delimiter
+	}
+	if opts.Comment != 0 {
+		csvReader.Comment = opts.Comment
+	}
+
+	var firstRecord []string
+	if opts.HasHeader {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return fmt.Errorf("unexpected end of file while reading CSV header")
+		}
+		if err != nil {
+			return fmt.Errorf("could not parse CSV header: %w", err)
+		}
+		c.FieldNames = record
+	} else {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return fmt.Errorf("unexpected end of file while reading CSV")
+		}
+		if err != nil {
+			return fmt.Errorf("could not parse CSV: %w", err)
+		}
+		c.FieldNames = make([]string, len(record))
+		for i := range record {
+			c.FieldNames[i] = fmt.Sprintf("column%d", i+1)
+		}
+		firstRecord = record
+	}
+
+	// Initialize FieldTypes to integer first; if we see a . in the data it switches to float
+	// if we see anything else it switches to string. When ConvertNumbers is disabled we skip
+	// inference altogether and keep every column a string.
+
+	c.FieldTypes = make([]FieldType, len(c.FieldNames))
+	for fieldIndex := range c.FieldNames {
+		if opts.ConvertNumbers {
+			c.FieldTypes[fieldIndex] = FieldTypeInteger
+		} else {
+			c.FieldTypes[fieldIndex] = FieldTypeString
+		}
+	}
+
+	// We don't know a column's final type until we've seen every row, so each chunk narrows
+	// FieldTypes (promoting int -> float -> string, never back) and then is promoted straight
+	// into the typed Columns and discarded. If a later chunk forces a column wider than what
+	// we've already stored, the column itself is widened in place (Column.widen) by re-parsing
+	// its own rendered values - this way we're never holding the whole file as raw strings and
+	// the whole file as typed columns at the same time.
+
+	c.Columns = make([]*Column, len(c.FieldNames))
+	for i, t := range c.FieldTypes {
+		c.Columns[i] = newColumn(t, 0)
+	}
+
+	rowsSoFar := 0
+	chunk := make([][]string, 0, chunkSize)
+
+	if firstRecord != nil {
+		chunk = append(chunk, firstRecord)
+	}
+
+	flushChunk := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		if opts.ConvertNumbers {
+			inferChunkTypesParallel(c.FieldTypes, chunk)
+
+			for i, t := range c.FieldTypes {
+				if c.Columns[i].Type != t {
+					c.Columns[i] = c.Columns[i].widen(t)
+				}
+			}
+		}
+
+		for _, row := range chunk {
+			for column, stringValue := range row {
+				c.Columns[column].append(stringValue)
+			}
+		}
+		rowsSoFar += len(chunk)
+		chunk = chunk[:0]
+
+		if opts.Progress != nil {
+			select {
+			case opts.Progress <- Progress{
+				RowsProcessed: rowsSoFar,
+				RowsEstimate:  estimateRowCount(sizeHint, counting.bytesRead, rowsSoFar),
+				BytesRead:     counting.bytesRead,
+			}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("import of %w", err)
+		}
+
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not parse CSV: %w", err)
+		}
+
+		chunk = append(chunk, record)
+
+		if len(chunk) >= chunkSize {
+			if err := flushChunk(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flushChunk(); err != nil {
+		return err
+	}
+
+	c.RowCount = rowsSoFar
+
+	log.Printf("finished processing CSV, %v rows processed", c.RowCount)
+
+	log.Println("fields: ")
+	for i, v := range c.FieldNames {
+		log.Printf("   %v: %v", v, fieldTypeString(c.FieldTypes[i]))
+	}
+
+	return nil
+}
+
+// inferChunkTypesParallel narrows fieldTypes in place based on a chunk of
+// records. Columns are independent of one another, so each one's
+// promotion rule (integer -> float -> string, never back) runs in its own
+// goroutine, joining at the chunk boundary.
+func inferChunkTypesParallel(fieldTypes []FieldType, chunk [][]string) {
+	var wg sync.WaitGroup
+
+	for column := range fieldTypes {
+		if fieldTypes[column] == FieldTypeString {
+			continue // already the most permissive type; nothing to narrow
+		}
+
+		wg.Add(1)
+		go func(column int) {
+			defer wg.Done()
+
+			for _, record := range chunk {
+				if column >= len(record) {
+					continue
+				}
+
+				value := record[column]
+				if value == "" {
+					// A blank cell carries no type information - it
+					// becomes a null row in Column.append regardless of
+					// the column's eventual type, so it must not demote
+					// an otherwise-numeric column to FieldTypeString.
+					continue
+				}
+
+				switch fieldTypes[column] {
+				case FieldTypeUnknown:
+					fieldTypes[column] = inferProbableTypeFromString(&value)
+				case FieldTypeInteger:
+					fieldTypes[column] = inferProbableTypeFromString(&value)
+				case FieldTypeFloat:
+					if inferProbableTypeFromString(&value) == FieldTypeString {
+						fieldTypes[column] = FieldTypeString
+						return
+					}
+				}
+			}
+		}(column)
+	}
+
+	wg.Wait()
+}
+
+// estimateRowCount extrapolates a total row count from how many bytes
+// CSV parsing has consumed so far, given the dataset's total byte size
+// (sizeHint, 0 if unknown). It returns 0 when it can't produce an
+// estimate, matching Progress.RowsEstimate's documented zero value.
+func estimateRowCount(sizeHint, bytesRead int64, rowsSoFar int) int {
+	if sizeHint <= 0 || bytesRead <= 0 || rowsSoFar == 0 {
+		return 0
+	}
+
+	avgBytesPerRow := float64(bytesRead) / float64(rowsSoFar)
+
+	return int(float64(sizeHint) / avgBytesPerRow)
+}
+
+// countingReader wraps an io.Reader, tallying bytes read so Progress can
+// report BytesRead and estimate total rows from average line length.
+type countingReader struct {
+	r         io.Reader
+	bytesRead int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.bytesRead += int64(n)
+
+	return n, err
+}
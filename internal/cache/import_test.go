@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportChunkedWithProgress(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("Region,Units\n")
+	for i := 0; i < 10; i++ {
+		sb.WriteString("East,1\n")
+	}
+
+	opts := DefaultImportOptions()
+	opts.ChunkSize = 3
+	progress := make(chan Progress, 100)
+	opts.Progress = progress
+
+	c := New()
+	if err := c.ImportReader(strings.NewReader(sb.String()), opts); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+	close(progress)
+
+	var updates []Progress
+	for p := range progress {
+		updates = append(updates, p)
+	}
+
+	if len(updates) == 0 {
+		t.Fatalf("expected at least one progress update")
+	}
+	last := updates[len(updates)-1]
+	if last.RowsProcessed != 10 {
+		t.Errorf("final RowsProcessed = %d, want 10", last.RowsProcessed)
+	}
+	// ImportReader can't estimate a total from a bare io.Reader.
+	if last.RowsEstimate != 0 {
+		t.Errorf("RowsEstimate = %d, want 0 for a reader-based import", last.RowsEstimate)
+	}
+}
+
+func TestImportPromotesTypeAcrossChunks(t *testing.T) {
+	// "Val" looks like an integer column in the first chunk, then a float
+	// shows up, then a plain string - each should force the already-stored
+	// column wider without losing earlier rows.
+	csv := "Name,Val\n" +
+		"a,1\nb,2\n" +
+		"c,3.5\nd,4\n" +
+		"e,hello\nf,6\n"
+
+	opts := DefaultImportOptions()
+	opts.ChunkSize = 2
+
+	c := New()
+	if err := c.ImportReader(strings.NewReader(csv), opts); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	if c.RowCount != 6 {
+		t.Fatalf("RowCount = %d, want 6", c.RowCount)
+	}
+
+	col, err := c.columnByName("Val")
+	if err != nil {
+		t.Fatalf("columnByName: %v", err)
+	}
+	if col.Type != FieldTypeString {
+		t.Fatalf("Val column ended up %v, want FieldTypeString", fieldTypeString(col.Type))
+	}
+
+	want := []string{"1", "2", "3.5", "4", "hello", "6"}
+	for i, w := range want {
+		if got := col.stringAt(i); got != w {
+			t.Errorf("row %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestImportContextCanceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sales.csv")
+	if err := os.WriteFile(path, []byte("Region,Units\nEast,1\nWest,2\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := New()
+	err := c.ImportContext(ctx, path, DefaultImportOptions())
+	if err == nil {
+		t.Fatalf("expected an error importing with an already-canceled context")
+	}
+}
+
+func TestEstimateRowCount(t *testing.T) {
+	tests := []struct {
+		sizeHint, bytesRead int64
+		rowsSoFar           int
+		want                int
+	}{
+		{0, 100, 10, 0},
+		{1000, 0, 10, 0},
+		{1000, 100, 0, 0},
+		{1000, 100, 10, 100},
+	}
+
+	for _, tc := range tests {
+		if got := estimateRowCount(tc.sizeHint, tc.bytesRead, tc.rowsSoFar); got != tc.want {
+			t.Errorf("estimateRowCount(%d, %d, %d) = %d, want %d", tc.sizeHint, tc.bytesRead, tc.rowsSoFar, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// SchemaVersion is the on-disk format version Save writes and the version
+// Load upgrades to. Bump it whenever onDiskCache's shape changes, and
+// register a MigrationFunc (via RegisterMigration) from the old version
+// so existing cache files on disk keep loading instead of forcing a
+// re-import of a multi-million-row CSV.
+const SchemaVersion = 1
+
+// onDiskCache is the gob-serializable mirror of DataSetCache, versioned so
+// that Load can tell which MigrationFunc chain to apply.
+type onDiskCache struct {
+	SchemaVersion int
+	FieldNames    []string
+	FieldTypes    []FieldType
+	RowCount      int
+	Columns       []onDiskColumn
+}
+
+type onDiskColumn struct {
+	Type    FieldType
+	Ints    []int64
+	Floats  []float64
+	Strings []string
+	Valid   []bool
+}
+
+// Save writes c's data and inferred schema to path in a gob-encoded,
+// versioned binary format, so a later Load can skip re-importing the
+// source CSV entirely.
+func (c *DataSetCache) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create cache file %v: %w", path, err)
+	}
+	defer f.Close()
+
+	onDisk := onDiskCache{
+		SchemaVersion: SchemaVersion,
+		FieldNames:    c.FieldNames,
+		FieldTypes:    c.FieldTypes,
+		RowCount:      c.RowCount,
+		Columns:       make([]onDiskColumn, len(c.Columns)),
+	}
+	for i, col := range c.Columns {
+		onDisk.Columns[i] = onDiskColumn{
+			Type:    col.Type,
+			Ints:    col.Ints,
+			Floats:  col.Floats,
+			Strings: col.Strings,
+			Valid:   col.Valid,
+		}
+	}
+
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(&onDisk); err != nil {
+		return fmt.Errorf("could not encode cache to %v: %w", path, err)
+	}
+
+	return w.Flush()
+}
+
+// Load reads a cache file written by Save. If it was written by an older
+// SchemaVersion, Load walks the MigrationFunc registry to bring it up to
+// the current version before returning it.
+func Load(path string) (*DataSetCache, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open cache file %v: %w", path, err)
+	}
+	defer f.Close()
+
+	var onDisk onDiskCache
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&onDisk); err != nil {
+		return nil, fmt.Errorf("could not decode cache file %v: %w", path, err)
+	}
+
+	c := onDisk.toDataSetCache()
+	version := onDisk.SchemaVersion
+
+	for version < SchemaVersion {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered to upgrade cache schema from version %d to %d", version, SchemaVersion)
+		}
+
+		upgraded, err := migrate(c)
+		if err != nil {
+			return nil, fmt.Errorf("migrating cache schema from version %d: %w", version, err)
+		}
+
+		c = upgraded
+		version++
+	}
+
+	return c, nil
+}
+
+func (onDisk *onDiskCache) toDataSetCache() *DataSetCache {
+	c := &DataSetCache{
+		FieldNames: onDisk.FieldNames,
+		FieldTypes: onDisk.FieldTypes,
+		RowCount:   onDisk.RowCount,
+		Columns:    make([]*Column, len(onDisk.Columns)),
+	}
+
+	for i, oc := range onDisk.Columns {
+		c.Columns[i] = &Column{
+			Type:    oc.Type,
+			Ints:    oc.Ints,
+			Floats:  oc.Floats,
+			Strings: oc.Strings,
+			Valid:   oc.Valid,
+		}
+	}
+
+	return c
+}
+
+// MigrationFunc upgrades a DataSetCache decoded from schema version vN's
+// on-disk format to the shape schema version vN+1 expects. This mirrors
+// Terraform's state-migration pattern: each migration only has to know
+// how to step forward one version, and Load chains them as needed.
+type MigrationFunc func(old *DataSetCache) (*DataSetCache, error)
+
+var migrations = map[int]MigrationFunc{}
+
+// RegisterMigration registers the upgrader run when Load encounters a
+// cache file written at fromVersion, bringing it to fromVersion+1.
+func RegisterMigration(fromVersion int, fn MigrationFunc) {
+	migrations[fromVersion] = fn
+}
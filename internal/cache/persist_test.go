@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	c := New()
+	csv := "Region,Units\nEast,1\nWest,2\n"
+	if err := c.ImportReader(strings.NewReader(csv), DefaultImportOptions()); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.bin")
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if loaded.RowCount != c.RowCount {
+		t.Errorf("RowCount = %d, want %d", loaded.RowCount, c.RowCount)
+	}
+	if len(loaded.Columns) != len(c.Columns) {
+		t.Fatalf("got %d columns, want %d", len(loaded.Columns), len(c.Columns))
+	}
+
+	res, err := loaded.Query("SELECT Region, Units FROM t WHERE Units > 1")
+	if err != nil {
+		t.Fatalf("Query on loaded cache: %v", err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "West" {
+		t.Errorf("Query on loaded cache = %v, want [[West 2]]", res.Rows)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load("/no/such/cache.bin"); err == nil {
+		t.Errorf("expected an error loading a missing cache file")
+	}
+}
+
+// writeOnDiskCache gob-encodes onDisk straight to path, the same way
+// DataSetCache.Save does, letting a test write a file claiming an
+// arbitrary SchemaVersion to exercise Load's migration chain.
+func writeOnDiskCache(t *testing.T, path string, onDisk onDiskCache) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := gob.NewEncoder(w).Encode(&onDisk); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+}
+
+func TestLoadRunsRegisteredMigration(t *testing.T) {
+	const oldVersion = SchemaVersion - 1
+
+	migrated := false
+	RegisterMigration(oldVersion, func(old *DataSetCache) (*DataSetCache, error) {
+		migrated = true
+		return old, nil
+	})
+	t.Cleanup(func() { delete(migrations, oldVersion) })
+
+	onDisk := onDiskCache{
+		SchemaVersion: oldVersion,
+		FieldNames:    []string{"Region"},
+		FieldTypes:    []FieldType{FieldTypeString},
+		RowCount:      1,
+		Columns: []onDiskColumn{
+			{Type: FieldTypeString, Strings: []string{"East"}, Valid: []bool{true}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "old.bin")
+	writeOnDiskCache(t, path, onDisk)
+
+	if _, err := Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !migrated {
+		t.Errorf("expected the registered migration to run")
+	}
+}
+
+func TestLoadMissingMigrationIsAnError(t *testing.T) {
+	onDisk := onDiskCache{SchemaVersion: SchemaVersion - 1000}
+	path := filepath.Join(t.TempDir(), "ancient.bin")
+	writeOnDiskCache(t, path, onDisk)
+
+	if _, err := Load(path); err == nil {
+		t.Errorf("expected an error loading a version with no registered migration path")
+	}
+}
@@ -0,0 +1,1115 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryResult holds the output of a Query: the projected/aggregated column
+// names in order, followed by each matching row as parallel string values.
+type QueryResult struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Query parses and executes a SQL-like SELECT statement against the cache.
+//
+// Supported grammar (keywords are case-insensitive):
+//
+//	SELECT <col>[, <col> ...] | *
+//	FROM <name>
+//	[WHERE <cond> [AND|OR <cond> ...]]
+//	[GROUP BY <col>[, <col> ...]]
+//	[ORDER BY <col> [ASC|DESC][, <col> [ASC|DESC] ...]]
+//
+// A <col> is either a bare field name, a double-quoted field name (needed
+// for names containing spaces, e.g. "Unit Price"), or an aggregate
+// expression: COUNT(*), COUNT(col), SUM(col), AVG(col), MIN(col), MAX(col).
+//
+// WHERE conditions are a flat chain of "<col> <op> <value>" comparisons
+// joined left-to-right by AND/OR; there is no operator precedence or
+// parenthesization. <op> is one of = != <> < <= > >= LIKE, and string
+// literals in a condition's value may be single-quoted.
+//
+// FROM is required for SQL familiarity but its value is never checked: a
+// DataSetCache only ever holds a single imported dataset.
+//
+// Comparisons against columns inferred as FieldTypeInteger or
+// FieldTypeFloat are done numerically so that e.g. "Units Sold" > 95
+// doesn't fall prey to a lexical sort; every other column compares
+// lexically.
+func (c *DataSetCache) Query(sql string) (*QueryResult, error) {
+	q, err := parseQuery(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(q)
+}
+
+// --- AST -------------------------------------------------------------
+
+type aggregateFunc int
+
+const (
+	aggNone aggregateFunc = iota
+	aggCount
+	aggSum
+	aggAvg
+	aggMin
+	aggMax
+)
+
+func aggregateFuncFromName(word string) (aggregateFunc, bool) {
+	switch strings.ToUpper(word) {
+	case "COUNT":
+		return aggCount, true
+	case "SUM":
+		return aggSum, true
+	case "AVG":
+		return aggAvg, true
+	case "MIN":
+		return aggMin, true
+	case "MAX":
+		return aggMax, true
+	}
+
+	return aggNone, false
+}
+
+func aggregateFuncName(agg aggregateFunc) string {
+	switch agg {
+	case aggCount:
+		return "COUNT"
+	case aggSum:
+		return "SUM"
+	case aggAvg:
+		return "AVG"
+	case aggMin:
+		return "MIN"
+	case aggMax:
+		return "MAX"
+	}
+
+	return ""
+}
+
+type selectExpr struct {
+	column string // field name, or "*" for SELECT * / COUNT(*)
+	agg    aggregateFunc
+}
+
+type condOp int
+
+const (
+	opEq condOp = iota
+	opNe
+	opLt
+	opLe
+	opGt
+	opGe
+	opLike
+)
+
+func condOpFromToken(s string) (condOp, error) {
+	switch s {
+	case "=":
+		return opEq, nil
+	case "!=", "<>":
+		return opNe, nil
+	case "<":
+		return opLt, nil
+	case "<=":
+		return opLe, nil
+	case ">":
+		return opGt, nil
+	case ">=":
+		return opGe, nil
+	}
+
+	if strings.EqualFold(s, "LIKE") {
+		return opLike, nil
+	}
+
+	return 0, fmt.Errorf("unsupported operator %q", s)
+}
+
+type condition struct {
+	column string
+	op     condOp
+	value  string
+}
+
+// condGroup is a flat chain of conditions joined left-to-right by AND/OR;
+// len(joins) == len(conditions)-1.
+type condGroup struct {
+	conditions []condition
+	joins      []string
+}
+
+// orderField is one ORDER BY term. agg is aggNone for a plain column
+// reference and set when the term is an aggregate call, e.g. COUNT(*) or
+// SUM(col) - the latter is only valid when the query is grouping or
+// otherwise aggregating.
+type orderField struct {
+	column string
+	agg    aggregateFunc
+	desc   bool
+}
+
+type query struct {
+	selects []selectExpr
+	from    string
+	where   *condGroup
+	groupBy []string
+	orderBy []orderField
+}
+
+// --- tokenizer ---------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota // bare identifier, keyword, number, or double-quoted identifier
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(sql)
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '\'' || r == '"':
+			quote := r
+			j := i + 1
+			var sb strings.Builder
+			for j < len(runes) && runes[j] != quote {
+				sb.WriteRune(runes[j])
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c in query", quote)
+			}
+			tokens = append(tokens, token{tokWord, sb.String()})
+			i = j + 1
+		case strings.ContainsRune("(),*", r):
+			tokens = append(tokens, token{tokPunct, string(r)})
+			i++
+		case strings.ContainsRune("=<>!", r):
+			j := i + 1
+			for j < len(runes) && strings.ContainsRune("=<>!", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokPunct, string(runes[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !isTokenBreak(runes[j]) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected character %q in query", r)
+			}
+			tokens = append(tokens, token{tokWord, string(runes[i:j])})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+func isTokenBreak(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' ||
+		strings.ContainsRune("(),'\"=<>!*", r)
+}
+
+// --- parser --------------------------------------------------------------
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+
+	return t, ok
+}
+
+func (p *parser) expectWord(word string) error {
+	t, ok := p.next()
+	if !ok || !strings.EqualFold(t.text, word) {
+		return fmt.Errorf("expected %q in query", word)
+	}
+
+	return nil
+}
+
+func (p *parser) expectPunct(punct string) error {
+	t, ok := p.next()
+	if !ok || t.text != punct {
+		return fmt.Errorf("expected %q in query", punct)
+	}
+
+	return nil
+}
+
+func parseQuery(sql string) (*query, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	if err := p.expectWord("SELECT"); err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	selects, err := p.parseSelectList()
+	if err != nil {
+		return nil, err
+	}
+	q.selects = selects
+
+	if err := p.expectWord("FROM"); err != nil {
+		return nil, err
+	}
+
+	from, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("expected table name after FROM")
+	}
+	q.from = from.text
+
+	for {
+		t, ok := p.peek()
+		if !ok {
+			break
+		}
+
+		switch strings.ToUpper(t.text) {
+		case "WHERE":
+			p.next()
+			cg, err := p.parseWhere()
+			if err != nil {
+				return nil, err
+			}
+			q.where = cg
+		case "GROUP":
+			p.next()
+			if err := p.expectWord("BY"); err != nil {
+				return nil, err
+			}
+			cols, err := p.parseColumnList()
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = cols
+		case "ORDER":
+			p.next()
+			if err := p.expectWord("BY"); err != nil {
+				return nil, err
+			}
+			fields, err := p.parseOrderList()
+			if err != nil {
+				return nil, err
+			}
+			q.orderBy = fields
+		default:
+			return nil, fmt.Errorf("unexpected token %q in query", t.text)
+		}
+	}
+
+	return q, nil
+}
+
+func (p *parser) parseSelectList() ([]selectExpr, error) {
+	var exprs []selectExpr
+
+	for {
+		t, ok := p.next()
+		if !ok {
+			return nil, fmt.Errorf("unexpected end of query in SELECT list")
+		}
+
+		switch {
+		case t.text == "*":
+			exprs = append(exprs, selectExpr{column: "*"})
+		default:
+			if agg, isAgg := aggregateFuncFromName(t.text); isAgg {
+				if err := p.expectPunct("("); err != nil {
+					return nil, err
+				}
+				col, ok := p.next()
+				if !ok {
+					return nil, fmt.Errorf("expected column or * inside %s(...)", t.text)
+				}
+				if err := p.expectPunct(")"); err != nil {
+					return nil, err
+				}
+				exprs = append(exprs, selectExpr{column: col.text, agg: agg})
+			} else {
+				exprs = append(exprs, selectExpr{column: t.text})
+			}
+		}
+
+		if nt, ok := p.peek(); ok && nt.text == "," {
+			p.next()
+			continue
+		}
+
+		break
+	}
+
+	return exprs, nil
+}
+
+func (p *parser) parseColumnList() ([]string, error) {
+	var cols []string
+
+	for {
+		t, ok := p.next()
+		if !ok {
+			return nil, fmt.Errorf("expected column name in query")
+		}
+		cols = append(cols, t.text)
+
+		if nt, ok := p.peek(); ok && nt.text == "," {
+			p.next()
+			continue
+		}
+
+		break
+	}
+
+	return cols, nil
+}
+
+func (p *parser) parseOrderList() ([]orderField, error) {
+	var fields []orderField
+
+	for {
+		t, ok := p.next()
+		if !ok {
+			return nil, fmt.Errorf("expected column name in ORDER BY")
+		}
+
+		of := orderField{column: t.text}
+		if agg, isAgg := aggregateFuncFromName(t.text); isAgg {
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			col, ok := p.next()
+			if !ok {
+				return nil, fmt.Errorf("expected column or * inside %s(...)", t.text)
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			of = orderField{column: col.text, agg: agg}
+		}
+
+		if nt, ok := p.peek(); ok {
+			switch strings.ToUpper(nt.text) {
+			case "ASC":
+				p.next()
+			case "DESC":
+				of.desc = true
+				p.next()
+			}
+		}
+		fields = append(fields, of)
+
+		if nt, ok := p.peek(); ok && nt.text == "," {
+			p.next()
+			continue
+		}
+
+		break
+	}
+
+	return fields, nil
+}
+
+func (p *parser) parseWhere() (*condGroup, error) {
+	cg := &condGroup{}
+
+	for {
+		cond, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		cg.conditions = append(cg.conditions, cond)
+
+		t, ok := p.peek()
+		if !ok {
+			break
+		}
+
+		upper := strings.ToUpper(t.text)
+		if upper == "AND" || upper == "OR" {
+			p.next()
+			cg.joins = append(cg.joins, upper)
+			continue
+		}
+
+		break
+	}
+
+	return cg, nil
+}
+
+func (p *parser) parseCondition() (condition, error) {
+	col, ok := p.next()
+	if !ok {
+		return condition{}, fmt.Errorf("expected column name in WHERE clause")
+	}
+
+	opTok, ok := p.next()
+	if !ok {
+		return condition{}, fmt.Errorf("expected comparison operator after %q", col.text)
+	}
+
+	op, err := condOpFromToken(opTok.text)
+	if err != nil {
+		return condition{}, err
+	}
+
+	valTok, ok := p.next()
+	if !ok {
+		return condition{}, fmt.Errorf("expected value after operator in WHERE clause")
+	}
+
+	return condition{column: col.text, op: op, value: valTok.text}, nil
+}
+
+// --- execution -----------------------------------------------------------
+
+func isNumericType(t FieldType) bool {
+	return t == FieldTypeInteger || t == FieldTypeFloat
+}
+
+func (c *DataSetCache) execute(q *query) (*QueryResult, error) {
+	var result QueryResult
+
+	err := c.executeInto(q,
+		func(columns []string) error {
+			result.Columns = columns
+			return nil
+		},
+		func(row []string) error {
+			result.Rows = append(result.Rows, row)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.Rows == nil {
+		result.Rows = [][]string{}
+	}
+
+	return &result, nil
+}
+
+// QueryStream parses and executes sql like Query, but reports the result
+// incrementally instead of buffering the whole thing: onColumns is called
+// once, as soon as the output column names are known, and onRow is called
+// once per output row as it's produced. This lets a caller such as the
+// HTTP /query handler start writing its response - and a caller's emit
+// error abort execution early - without materializing a QueryResult
+// holding a potentially multi-million-row [][]string in memory first.
+func (c *DataSetCache) QueryStream(sql string, onColumns func([]string) error, onRow func([]string) error) error {
+	q, err := parseQuery(sql)
+	if err != nil {
+		return err
+	}
+
+	return c.executeInto(q, onColumns, onRow)
+}
+
+func (c *DataSetCache) executeInto(q *query, onColumns func([]string) error, onRow func([]string) error) error {
+	colIndex := make(map[string]int, len(c.FieldNames))
+	for i, name := range c.FieldNames {
+		colIndex[name] = i
+	}
+
+	rowIndexes := make([]int, 0, c.RowCount)
+	for i := 0; i < c.RowCount; i++ {
+		ok, err := q.where.matches(c, colIndex, i)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rowIndexes = append(rowIndexes, i)
+		}
+	}
+
+	aggregating := len(q.groupBy) > 0 || hasAggregate(q.selects)
+
+	if !aggregating {
+		for _, of := range q.orderBy {
+			if of.agg != aggNone {
+				return fmt.Errorf("ORDER BY %s(%s) requires GROUP BY or an aggregate in SELECT", aggregateFuncName(of.agg), of.column)
+			}
+		}
+
+		if len(q.orderBy) > 0 {
+			if err := sortRows(c, colIndex, rowIndexes, q.orderBy); err != nil {
+				return err
+			}
+		}
+
+		return c.executeProjectionInto(q, colIndex, rowIndexes, onColumns, onRow)
+	}
+
+	// Grouping/aggregation collapses many rows into one per group, so ORDER BY
+	// here has to sort the computed groups - by a GROUP BY column or by an
+	// aggregate expression - rather than the pre-aggregation rowIndexes.
+	return c.executeAggregateInto(q, colIndex, rowIndexes, onColumns, onRow)
+}
+
+func (cg *condGroup) matches(c *DataSetCache, colIndex map[string]int, row int) (bool, error) {
+	if cg == nil || len(cg.conditions) == 0 {
+		return true, nil
+	}
+
+	result, err := cg.conditions[0].matches(c, colIndex, row)
+	if err != nil {
+		return false, err
+	}
+
+	for i, join := range cg.joins {
+		next, err := cg.conditions[i+1].matches(c, colIndex, row)
+		if err != nil {
+			return false, err
+		}
+		if join == "AND" {
+			result = result && next
+		} else {
+			result = result || next
+		}
+	}
+
+	return result, nil
+}
+
+func (cond *condition) matches(c *DataSetCache, colIndex map[string]int, row int) (bool, error) {
+	idx, ok := colIndex[cond.column]
+	if !ok {
+		return false, fmt.Errorf("unknown column %q", cond.column)
+	}
+
+	if cond.op == opLike {
+		return matchLike(c.StringAt(row, idx), cond.value), nil
+	}
+
+	if isNumericType(c.FieldTypes[idx]) {
+		av, aok := c.Columns[idx].numericAt(row)
+		bv, berr := strconv.ParseFloat(cond.value, 64)
+		if aok && berr == nil {
+			return compareNumeric(av, bv, cond.op), nil
+		}
+		// fall through to a lexical compare if either side doesn't parse,
+		// e.g. an empty cell in an otherwise-numeric column
+	}
+
+	return compareLexical(c.StringAt(row, idx), cond.value, cond.op), nil
+}
+
+func compareNumeric(a, b float64, op condOp) bool {
+	switch op {
+	case opEq:
+		return a == b
+	case opNe:
+		return a != b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	}
+
+	return false
+}
+
+func compareLexical(a, b string, op condOp) bool {
+	switch op {
+	case opEq:
+		return a == b
+	case opNe:
+		return a != b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	}
+
+	return false
+}
+
+// matchLike implements a minimal, case-sensitive SQL LIKE: '%' matches any
+// run of characters (including none) and '_' matches exactly one character.
+func matchLike(s, pattern string) bool {
+	return likeMatch([]rune(s), []rune(pattern))
+}
+
+func likeMatch(s, p []rune) bool {
+	if len(p) == 0 {
+		return len(s) == 0
+	}
+
+	switch p[0] {
+	case '%':
+		if likeMatch(s, p[1:]) {
+			return true
+		}
+		for len(s) > 0 {
+			s = s[1:]
+			if likeMatch(s, p[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		if len(s) == 0 {
+			return false
+		}
+		return likeMatch(s[1:], p[1:])
+	default:
+		if len(s) == 0 || s[0] != p[0] {
+			return false
+		}
+		return likeMatch(s[1:], p[1:])
+	}
+}
+
+func sortRows(c *DataSetCache, colIndex map[string]int, rowIndexes []int, orderBy []orderField) error {
+	type sortField struct {
+		idx     int
+		desc    bool
+		numeric bool
+	}
+
+	fields := make([]sortField, len(orderBy))
+	for i, of := range orderBy {
+		idx, ok := colIndex[of.column]
+		if !ok {
+			return fmt.Errorf("unknown column %q in ORDER BY", of.column)
+		}
+		fields[i] = sortField{idx: idx, desc: of.desc, numeric: isNumericType(c.FieldTypes[idx])}
+	}
+
+	sort.SliceStable(rowIndexes, func(i, j int) bool {
+		ri, rj := rowIndexes[i], rowIndexes[j]
+
+		for _, f := range fields {
+			var less, greater bool
+
+			if f.numeric {
+				av, aok := c.Columns[f.idx].numericAt(ri)
+				bv, bok := c.Columns[f.idx].numericAt(rj)
+				if aok && bok {
+					less, greater = av < bv, av > bv
+				} else {
+					a, b := c.StringAt(ri, f.idx), c.StringAt(rj, f.idx)
+					less, greater = a < b, a > b
+				}
+			} else {
+				a, b := c.StringAt(ri, f.idx), c.StringAt(rj, f.idx)
+				less, greater = a < b, a > b
+			}
+
+			if !less && !greater {
+				continue
+			}
+
+			if f.desc {
+				return greater
+			}
+			return less
+		}
+
+		return false
+	})
+
+	return nil
+}
+
+func hasAggregate(selects []selectExpr) bool {
+	for _, s := range selects {
+		if s.agg != aggNone {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *DataSetCache) executeProjectionInto(q *query, colIndex map[string]int, rowIndexes []int, onColumns func([]string) error, onRow func([]string) error) error {
+	star := len(q.selects) == 1 && q.selects[0].column == "*"
+
+	var columns []string
+	var indexes []int
+
+	if star {
+		columns = append([]string(nil), c.FieldNames...)
+		indexes = make([]int, len(c.FieldNames))
+		for i := range c.FieldNames {
+			indexes[i] = i
+		}
+	} else {
+		columns = make([]string, len(q.selects))
+		indexes = make([]int, len(q.selects))
+		for i, sel := range q.selects {
+			idx, ok := colIndex[sel.column]
+			if !ok {
+				return fmt.Errorf("unknown column %q", sel.column)
+			}
+			columns[i] = sel.column
+			indexes[i] = idx
+		}
+	}
+
+	if err := onColumns(columns); err != nil {
+		return err
+	}
+
+	for _, rowIdx := range rowIndexes {
+		row := make([]string, len(indexes))
+		for j, colIdx := range indexes {
+			row[j] = c.StringAt(rowIdx, colIdx)
+		}
+		if err := onRow(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// group is one GROUP BY bucket: the GROUP BY column values that identify
+// it (for output) and the indexes of every row that fell into it.
+type group struct {
+	vals []string
+	rows []int
+}
+
+func (c *DataSetCache) executeAggregateInto(q *query, colIndex map[string]int, rowIndexes []int, onColumns func([]string) error, onRow func([]string) error) error {
+	groupIdx := make([]int, len(q.groupBy))
+	for i, col := range q.groupBy {
+		idx, ok := colIndex[col]
+		if !ok {
+			return fmt.Errorf("unknown column %q in GROUP BY", col)
+		}
+		groupIdx[i] = idx
+	}
+
+	order := make([]string, 0)
+	groups := make(map[string]*group)
+
+	for _, rowIdx := range rowIndexes {
+		var key strings.Builder
+		vals := make([]string, len(groupIdx))
+		for i, idx := range groupIdx {
+			vals[i] = c.StringAt(rowIdx, idx)
+			key.WriteString(vals[i])
+			key.WriteByte(0x1f)
+		}
+
+		g, ok := groups[key.String()]
+		if !ok {
+			g = &group{vals: vals}
+			groups[key.String()] = g
+			order = append(order, key.String())
+		}
+		g.rows = append(g.rows, rowIdx)
+	}
+
+	if len(groupIdx) == 0 {
+		// No GROUP BY: the whole result set is a single group, even when
+		// empty, so e.g. COUNT(*) over zero matching rows still returns 0.
+		groups[""] = &group{rows: rowIndexes}
+		order = []string{""}
+	}
+
+	if len(q.orderBy) > 0 {
+		if err := c.sortGroups(q, colIndex, groups, order); err != nil {
+			return err
+		}
+	}
+
+	columns := make([]string, len(q.selects))
+	for i, sel := range q.selects {
+		columns[i] = selectLabel(sel)
+	}
+	if err := onColumns(columns); err != nil {
+		return err
+	}
+
+	for _, key := range order {
+		g := groups[key]
+
+		groupValByName := make(map[string]string, len(q.groupBy))
+		for i, col := range q.groupBy {
+			groupValByName[col] = g.vals[i]
+		}
+
+		row := make([]string, len(q.selects))
+		for i, sel := range q.selects {
+			if sel.agg == aggNone {
+				v, ok := groupValByName[sel.column]
+				if !ok {
+					return fmt.Errorf("column %q must appear in GROUP BY or be an aggregate", sel.column)
+				}
+				row[i] = v
+				continue
+			}
+
+			cell, err := c.aggregateCell(sel, colIndex, g.rows)
+			if err != nil {
+				return err
+			}
+			row[i] = cell.str
+		}
+
+		if err := onRow(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortGroups reorders order (the group keys, in first-seen order) in place
+// to satisfy q.orderBy, resolving each order term against either a GROUP BY
+// column's value for that group or a freshly-computed aggregate over the
+// group's rows.
+func (c *DataSetCache) sortGroups(q *query, colIndex map[string]int, groups map[string]*group, order []string) error {
+	var sortErr error
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+
+		gi, gj := groups[order[i]], groups[order[j]]
+
+		for _, of := range q.orderBy {
+			a, err := c.orderCellForGroup(of, q, colIndex, gi)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			b, err := c.orderCellForGroup(of, q, colIndex, gj)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+
+			less, greater := compareCells(a, b)
+			if !less && !greater {
+				continue
+			}
+
+			if of.desc {
+				return greater
+			}
+			return less
+		}
+
+		return false
+	})
+
+	return sortErr
+}
+
+// orderCellForGroup resolves one ORDER BY term against a single aggregated
+// group: a plain column name must match a GROUP BY column and reads that
+// group's value for it, while an aggregate term (e.g. COUNT(*)) is computed
+// fresh over the group's rows.
+func (c *DataSetCache) orderCellForGroup(of orderField, q *query, colIndex map[string]int, g *group) (cellValue, error) {
+	if of.agg == aggNone {
+		for i, col := range q.groupBy {
+			if col == of.column {
+				return cellValueFromString(of.column, g.vals[i], c, colIndex), nil
+			}
+		}
+
+		return cellValue{}, fmt.Errorf("ORDER BY %q must appear in GROUP BY or be an aggregate", of.column)
+	}
+
+	return c.aggregateCell(selectExpr{column: of.column, agg: of.agg}, colIndex, g.rows)
+}
+
+// cellValueFromString builds a cellValue for a GROUP BY column's value,
+// marking it numeric when the underlying column was inferred as such so
+// compareCells sorts it numerically rather than lexically.
+func cellValueFromString(column, value string, c *DataSetCache, colIndex map[string]int) cellValue {
+	idx, ok := colIndex[column]
+	if !ok || !isNumericType(c.FieldTypes[idx]) {
+		return cellValue{str: value}
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return cellValue{str: value}
+	}
+
+	return cellValue{str: value, num: f, numeric: true}
+}
+
+// compareCells reports whether a sorts before (less) or after (greater) b.
+// Numeric cells compare by num; anything else falls back to a lexical
+// compare on str.
+func compareCells(a, b cellValue) (less, greater bool) {
+	if a.numeric && b.numeric {
+		return a.num < b.num, a.num > b.num
+	}
+
+	return a.str < b.str, a.str > b.str
+}
+
+func selectLabel(sel selectExpr) string {
+	if sel.agg == aggNone {
+		return sel.column
+	}
+
+	return fmt.Sprintf("%s(%s)", aggregateFuncName(sel.agg), sel.column)
+}
+
+// cellValue is a single computed output value carrying both its rendered
+// string form and, when numeric, the float64 used to compare it against
+// other cells without reparsing - see compareCells.
+type cellValue struct {
+	str     string
+	num     float64
+	numeric bool
+}
+
+func (c *DataSetCache) aggregateCell(sel selectExpr, colIndex map[string]int, rows []int) (cellValue, error) {
+	if sel.agg == aggCount {
+		n := len(rows)
+		return cellValue{str: strconv.Itoa(n), num: float64(n), numeric: true}, nil
+	}
+
+	idx, ok := colIndex[sel.column]
+	if !ok {
+		return cellValue{}, fmt.Errorf("unknown column %q", sel.column)
+	}
+
+	numeric := isNumericType(c.FieldTypes[idx])
+
+	var sum float64
+	var count int
+	var minStr, maxStr string
+	var minF, maxF float64
+	haveExtreme := false
+
+	col := c.Columns[idx]
+
+	for _, rowIdx := range rows {
+		switch sel.agg {
+		case aggMin, aggMax:
+			if numeric {
+				f, ok := col.numericAt(rowIdx)
+				if !ok {
+					continue
+				}
+				if !haveExtreme || f < minF {
+					minF = f
+				}
+				if !haveExtreme || f > maxF {
+					maxF = f
+				}
+				haveExtreme = true
+			} else {
+				v := c.StringAt(rowIdx, idx)
+				if !haveExtreme || v < minStr {
+					minStr = v
+				}
+				if !haveExtreme || v > maxStr {
+					maxStr = v
+				}
+				haveExtreme = true
+			}
+		case aggSum, aggAvg:
+			f, ok := col.numericAt(rowIdx)
+			if !ok && !numeric {
+				// Non-numeric column (e.g. promoted to string by a stray
+				// value): fall back to a per-value parse, same leniency
+				// as before typed columns existed.
+				var err error
+				f, err = strconv.ParseFloat(c.StringAt(rowIdx, idx), 64)
+				ok = err == nil
+			}
+			if !ok {
+				continue // skip unparsable/null values, same leniency as type inference
+			}
+			sum += f
+			count++
+		}
+	}
+
+	switch sel.agg {
+	case aggSum:
+		return cellValue{str: formatFloat(sum), num: sum, numeric: true}, nil
+	case aggAvg:
+		if count == 0 {
+			return cellValue{str: "0", numeric: true}, nil
+		}
+		avg := sum / float64(count)
+		return cellValue{str: formatFloat(avg), num: avg, numeric: true}, nil
+	case aggMin:
+		if numeric {
+			return cellValue{str: formatFloat(minF), num: minF, numeric: true}, nil
+		}
+		return cellValue{str: minStr}, nil
+	case aggMax:
+		if numeric {
+			return cellValue{str: formatFloat(maxF), num: maxF, numeric: true}, nil
+		}
+		return cellValue{str: maxStr}, nil
+	}
+
+	return cellValue{}, fmt.Errorf("unsupported aggregate")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
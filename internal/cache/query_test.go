@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestCache(t *testing.T, csvData string) *DataSetCache {
+	t.Helper()
+
+	c := New()
+	if err := c.ImportReader(strings.NewReader(csvData), DefaultImportOptions()); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	return c
+}
+
+const salesCSV = `Region,Units
+East,1
+West,2
+East,3
+West,4
+East,5
+`
+
+func TestQuerySelectWhere(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	res, err := c.Query("SELECT Region, Units FROM t WHERE Units > 2 ORDER BY Units DESC")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	want := [][]string{{"East", "5"}, {"West", "4"}, {"East", "3"}}
+	if len(res.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(res.Rows), len(want), res.Rows)
+	}
+	for i, row := range want {
+		if res.Rows[i][0] != row[0] || res.Rows[i][1] != row[1] {
+			t.Errorf("row %d = %v, want %v", i, res.Rows[i], row)
+		}
+	}
+}
+
+func TestQueryGroupByAggregate(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	res, err := c.Query("SELECT Region, COUNT(*), SUM(Units) FROM t GROUP BY Region")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	got := map[string][]string{}
+	for _, row := range res.Rows {
+		got[row[0]] = row[1:]
+	}
+
+	if got["East"][0] != "3" || got["East"][1] != "9" {
+		t.Errorf("East group = %v, want [3 9]", got["East"])
+	}
+	if got["West"][0] != "2" || got["West"][1] != "6" {
+		t.Errorf("West group = %v, want [2 6]", got["West"])
+	}
+}
+
+func TestQueryOrderByAggregate(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	res, err := c.Query("SELECT Region, COUNT(*) FROM t GROUP BY Region ORDER BY COUNT(*) DESC")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(res.Rows) != 2 || res.Rows[0][0] != "East" || res.Rows[1][0] != "West" {
+		t.Fatalf("rows = %v, want East first (count 3) then West (count 2)", res.Rows)
+	}
+}
+
+func TestQueryOrderByAggregateWithoutGroupingIsAnError(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	_, err := c.Query("SELECT * FROM t ORDER BY COUNT(*)")
+	if err == nil {
+		t.Fatalf("expected an error ordering by an aggregate with no GROUP BY/aggregate SELECT")
+	}
+}
+
+func TestQueryLike(t *testing.T) {
+	c := newTestCache(t, "Name\nAlice\nBob\nAlbert\n")
+
+	res, err := c.Query("SELECT Name FROM t WHERE Name LIKE 'Al%'")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if len(res.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %v", len(res.Rows), res.Rows)
+	}
+}
+
+func TestQueryNumericOrderByAvoidsLexicalSort(t *testing.T) {
+	// Lexical order would be "10, 100, 9, 95.2"; numeric order must not.
+	c := newTestCache(t, "Name,Score\nA,9\nB,10\nC,95.2\nD,100\n")
+
+	res, err := c.Query("SELECT Name FROM t ORDER BY Score ASC")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	want := []string{"A", "B", "C", "D"}
+	if len(res.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(res.Rows), len(want), res.Rows)
+	}
+	for i, name := range want {
+		if res.Rows[i][0] != name {
+			t.Errorf("row %d = %v, want Name %q", i, res.Rows[i], name)
+		}
+	}
+}
+
+func TestQueryNumericOrderByWithBlankCell(t *testing.T) {
+	// A blank Score must not demote the whole column to string comparison -
+	// the three real numbers still have to come back in numeric order.
+	c := newTestCache(t, "Name,Score\nA,9\nB,10\nC,\nD,100\n")
+
+	if col, err := c.columnByName("Score"); err != nil || col.Type != FieldTypeInteger {
+		t.Fatalf("Score column = %v (err %v), want FieldTypeInteger", col, err)
+	}
+
+	res, err := c.Query("SELECT Name, Score FROM t ORDER BY Score ASC")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	numeric := make([]string, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		if row[1] != "" {
+			numeric = append(numeric, row[0])
+		}
+	}
+
+	want := []string{"A", "B", "D"}
+	if len(numeric) != len(want) {
+		t.Fatalf("got numeric rows %v, want %v", numeric, want)
+	}
+	for i, name := range want {
+		if numeric[i] != name {
+			t.Errorf("numeric row %d = %q, want %q", i, numeric[i], name)
+		}
+	}
+}
+
+func TestQueryNumericWhereWithBlankCell(t *testing.T) {
+	c := newTestCache(t, "Name,Score\nA,9\nB,10\nC,\nD,100\n")
+
+	res, err := c.Query("SELECT Name FROM t WHERE Score > 9")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, row := range res.Rows {
+		got[row[0]] = true
+	}
+	if !got["B"] || !got["D"] || got["A"] || got["C"] {
+		t.Errorf("WHERE Score > 9 rows = %v, want exactly B and D", res.Rows)
+	}
+}
+
+func TestQueryStreamMatchesQuery(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	want, err := c.Query("SELECT Region, COUNT(*) FROM t GROUP BY Region ORDER BY COUNT(*) DESC")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	var gotColumns []string
+	var gotRows [][]string
+	err = c.QueryStream("SELECT Region, COUNT(*) FROM t GROUP BY Region ORDER BY COUNT(*) DESC",
+		func(cols []string) error {
+			gotColumns = cols
+			return nil
+		},
+		func(row []string) error {
+			gotRows = append(gotRows, row)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("QueryStream: %v", err)
+	}
+
+	if len(gotColumns) != len(want.Columns) || gotColumns[0] != want.Columns[0] {
+		t.Errorf("columns = %v, want %v", gotColumns, want.Columns)
+	}
+	if len(gotRows) != len(want.Rows) {
+		t.Fatalf("got %d rows, want %d", len(gotRows), len(want.Rows))
+	}
+	for i := range want.Rows {
+		if gotRows[i][0] != want.Rows[i][0] || gotRows[i][1] != want.Rows[i][1] {
+			t.Errorf("row %d = %v, want %v", i, gotRows[i], want.Rows[i])
+		}
+	}
+}
+
+func TestQueryStreamStopsOnRowError(t *testing.T) {
+	c := newTestCache(t, salesCSV)
+
+	rowErr := fmt.Errorf("client went away")
+	calls := 0
+	err := c.QueryStream("SELECT * FROM t",
+		func([]string) error { return nil },
+		func([]string) error {
+			calls++
+			return rowErr
+		},
+	)
+	if err != rowErr {
+		t.Fatalf("QueryStream err = %v, want %v", err, rowErr)
+	}
+	if calls != 1 {
+		t.Errorf("onRow called %d times, want exactly 1 (stop at the first error)", calls)
+	}
+}
+
+func TestParseQueryRejectsUnexpectedToken(t *testing.T) {
+	_, err := parseQuery("SELECT * FROM t GARBAGE")
+	if err == nil {
+		t.Fatalf("expected a parse error for an unexpected trailing token")
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceForURI(t *testing.T) {
+	tests := []struct {
+		uri      string
+		wantType interface{}
+	}{
+		{"data/sales.csv", fileSource{}},
+		{"file:///tmp/sales.csv", fileSource{}},
+		{"http://example.com/sales.csv", httpSource{}},
+		{"https://example.com/sales.csv", httpSource{}},
+		{"s3://my-bucket/path/to/sales.csv", s3Source{}},
+	}
+
+	for _, tc := range tests {
+		src, err := sourceForURI(tc.uri)
+		if err != nil {
+			t.Errorf("sourceForURI(%q): %v", tc.uri, err)
+			continue
+		}
+
+		switch tc.wantType.(type) {
+		case fileSource:
+			if _, ok := src.(fileSource); !ok {
+				t.Errorf("sourceForURI(%q) = %T, want fileSource", tc.uri, src)
+			}
+		case httpSource:
+			if _, ok := src.(httpSource); !ok {
+				t.Errorf("sourceForURI(%q) = %T, want httpSource", tc.uri, src)
+			}
+		case s3Source:
+			s3, ok := src.(s3Source)
+			if !ok {
+				t.Errorf("sourceForURI(%q) = %T, want s3Source", tc.uri, src)
+				continue
+			}
+			if s3.bucket != "my-bucket" || s3.key != "path/to/sales.csv" {
+				t.Errorf("s3Source = %+v, want bucket=my-bucket key=path/to/sales.csv", s3)
+			}
+		}
+	}
+
+	if _, err := sourceForURI("ftp://example.com/sales.csv"); err == nil {
+		t.Errorf("expected an error for an unsupported scheme")
+	}
+}
+
+func TestFileSourceOpenAndSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sales.csv")
+	if err := os.WriteFile(path, []byte("Region\nEast\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	src := fileSource{path: path}
+
+	size, ok := src.Size(context.Background())
+	if !ok || size != 12 {
+		t.Errorf("Size() = (%d, %v), want (12, true)", size, ok)
+	}
+
+	rc, err := src.Open(context.Background())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rc.Close()
+
+	var buf strings.Builder
+	bytes := make([]byte, 64)
+	n, _ := rc.Read(bytes)
+	buf.Write(bytes[:n])
+	if got, want := buf.String(), "Region\nEast\n"; got != want {
+		t.Errorf("read %q, want %q", got, want)
+	}
+}
+
+func TestFileSourceOpenMissingFile(t *testing.T) {
+	src := fileSource{path: "/no/such/file.csv"}
+	if _, err := src.Open(context.Background()); err == nil {
+		t.Errorf("expected an error opening a missing file")
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("Region\nEast\n"))
+	}))
+	defer srv.Close()
+
+	src := httpSource{url: srv.URL + "/sales.csv"}
+	rc, err := src.Open(context.Background())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, 64)
+	n, _ := rc.Read(buf)
+	if got, want := string(buf[:n]), "Region\nEast\n"; got != want {
+		t.Errorf("read %q, want %q", got, want)
+	}
+
+	badSrc := httpSource{url: srv.URL + "/missing"}
+	if _, err := badSrc.Open(context.Background()); err == nil {
+		t.Errorf("expected an error for a non-200 response")
+	}
+}
+
+func TestReaderSource(t *testing.T) {
+	src := readerSource{r: strings.NewReader("Region\nEast\n")}
+	rc, err := src.Open(context.Background())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, 64)
+	n, _ := rc.Read(buf)
+	if got, want := string(buf[:n]), "Region\nEast\n"; got != want {
+		t.Errorf("read %q, want %q", got, want)
+	}
+}
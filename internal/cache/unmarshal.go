@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	csvTagName    = "csv"
+	layoutTagName = "layout"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// DecodeRow maps row i onto the exported fields of the struct pointed to by
+// dst. Fields are matched to columns by a `csv:"Column Name"` tag or, when
+// no tag is present, the field name with underscores replaced by spaces
+// (so an "OrderDate" tag isn't needed for a column named "Order Date" only
+// if you name the field Order_Date; otherwise add an explicit tag). A
+// column with no matching field, or a field with no matching column, is
+// left alone.
+//
+// Numeric fields (any int or float kind) are parsed according to the
+// column's inferred FieldType. A time.Time field is parsed using the
+// layout in its `layout:"..."` tag, defaulting to time.RFC3339. Pointer
+// fields are left nil for a null cell and otherwise allocated and set,
+// giving nullable columns an idiomatic way to come back as nil.
+func (c *DataSetCache) DecodeRow(i int, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cache: DecodeRow requires a pointer to a struct, got %T", dst)
+	}
+	if i < 0 || i >= c.RowCount {
+		return fmt.Errorf("cache: row index %d out of range [0, %d)", i, c.RowCount)
+	}
+
+	plans := c.fieldPlans(v.Elem().Type())
+
+	return c.decodeRowInto(i, v.Elem(), plans)
+}
+
+// Unmarshal decodes every row into dst, which must be a pointer to a slice
+// of structs (or of pointers to structs), using the same field/column
+// matching rules as DecodeRow. It gives callers an idiomatic, typed view
+// of an imported dataset instead of walking Columns by hand.
+func (c *DataSetCache) Unmarshal(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cache: Unmarshal requires a pointer to a slice, got %T", dst)
+	}
+
+	sliceValue := v.Elem()
+	elemType := sliceValue.Type().Elem()
+	elemIsPtr := elemType.Kind() == reflect.Ptr
+
+	structType := elemType
+	if elemIsPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("cache: Unmarshal requires a slice of structs, got %T", dst)
+	}
+
+	plans := c.fieldPlans(structType)
+
+	out := reflect.MakeSlice(sliceValue.Type(), c.RowCount, c.RowCount)
+	for i := 0; i < c.RowCount; i++ {
+		structPtr := reflect.New(structType)
+		if err := c.decodeRowInto(i, structPtr.Elem(), plans); err != nil {
+			return fmt.Errorf("cache: row %d: %w", i, err)
+		}
+
+		if elemIsPtr {
+			out.Index(i).Set(structPtr)
+		} else {
+			out.Index(i).Set(structPtr.Elem())
+		}
+	}
+
+	sliceValue.Set(out)
+
+	return nil
+}
+
+// fieldPlan records which struct field maps to which imported column, so
+// the tag/name matching only has to happen once per Unmarshal/DecodeRow
+// call rather than once per row.
+type fieldPlan struct {
+	fieldIndex int
+	colIndex   int
+}
+
+func (c *DataSetCache) fieldPlans(structType reflect.Type) []fieldPlan {
+	colIndex := make(map[string]int, len(c.FieldNames))
+	for i, name := range c.FieldNames {
+		colIndex[name] = i
+	}
+
+	var plans []fieldPlan
+	for f := 0; f < structType.NumField(); f++ {
+		field := structType.Field(f)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		colName := field.Tag.Get(csvTagName)
+		if colName == "-" {
+			continue
+		}
+		if colName == "" {
+			colName = strings.ReplaceAll(field.Name, "_", " ")
+		}
+
+		idx, ok := colIndex[colName]
+		if !ok {
+			continue
+		}
+
+		plans = append(plans, fieldPlan{fieldIndex: f, colIndex: idx})
+	}
+
+	return plans
+}
+
+func (c *DataSetCache) decodeRowInto(row int, structVal reflect.Value, plans []fieldPlan) error {
+	structType := structVal.Type()
+
+	for _, plan := range plans {
+		field := structType.Field(plan.fieldIndex)
+		fieldValue := structVal.Field(plan.fieldIndex)
+		col := c.Columns[plan.colIndex]
+		valid := col.Valid[row]
+
+		if fieldValue.Kind() == reflect.Ptr {
+			if !valid {
+				continue // leave nil
+			}
+			elem := reflect.New(fieldValue.Type().Elem())
+			if err := setFieldValue(elem.Elem(), col, row, field); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			fieldValue.Set(elem)
+			continue
+		}
+
+		if !valid {
+			continue // leave the zero value
+		}
+
+		if err := setFieldValue(fieldValue, col, row, field); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setFieldValue(fieldValue reflect.Value, col *Column, row int, field reflect.StructField) error {
+	if fieldValue.Type() == timeType {
+		layout := field.Tag.Get(layoutTagName)
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		raw := col.stringAt(row)
+		t, err := time.Parse(layout, raw)
+		if err != nil {
+			return fmt.Errorf("could not parse %q as time with layout %q: %w", raw, layout, err)
+		}
+		fieldValue.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(col.stringAt(row))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := intValueAt(col, row)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := floatValueAt(col, row)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetFloat(f)
+	case reflect.Bool:
+		raw := col.stringAt(row)
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("could not parse %q as bool: %w", raw, err)
+		}
+		fieldValue.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", fieldValue.Type())
+	}
+
+	return nil
+}
+
+func intValueAt(col *Column, row int) (int64, error) {
+	switch col.Type {
+	case FieldTypeInteger:
+		return col.Ints[row], nil
+	case FieldTypeFloat:
+		return int64(col.Floats[row]), nil
+	default:
+		raw := col.Strings[row]
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %q as int: %w", raw, err)
+		}
+		return v, nil
+	}
+}
+
+func floatValueAt(col *Column, row int) (float64, error) {
+	switch col.Type {
+	case FieldTypeFloat:
+		return col.Floats[row], nil
+	case FieldTypeInteger:
+		return float64(col.Ints[row]), nil
+	default:
+		raw := col.Strings[row]
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse %q as float: %w", raw, err)
+		}
+		return v, nil
+	}
+}
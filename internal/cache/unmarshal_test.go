@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type salesRow struct {
+	Region    string
+	Units     int
+	UnitPrice float64   `csv:"Unit Price"`
+	OrderDate time.Time `csv:"Order Date" layout:"2006-01-02"`
+	Ignored   string    `csv:"-"`
+}
+
+const unmarshalCSV = `Region,Units,Unit Price,Order Date
+East,3,9.5,2024-01-15
+West,4,12.25,2024-02-20
+`
+
+func TestUnmarshal(t *testing.T) {
+	c := New()
+	if err := c.ImportReader(strings.NewReader(unmarshalCSV), DefaultImportOptions()); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	var rows []salesRow
+	if err := c.Unmarshal(&rows); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	first := rows[0]
+	if first.Region != "East" || first.Units != 3 || first.UnitPrice != 9.5 {
+		t.Errorf("rows[0] = %+v, unexpected values", first)
+	}
+	wantDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if !first.OrderDate.Equal(wantDate) {
+		t.Errorf("rows[0].OrderDate = %v, want %v", first.OrderDate, wantDate)
+	}
+
+	if rows[1].Units != 4 || rows[1].UnitPrice != 12.25 {
+		t.Errorf("rows[1] = %+v, unexpected values", rows[1])
+	}
+}
+
+func TestDecodeRowPointerFieldNullability(t *testing.T) {
+	type row struct {
+		Units *int64
+	}
+
+	c := &DataSetCache{
+		FieldNames: []string{"Units"},
+		FieldTypes: []FieldType{FieldTypeInteger},
+		RowCount:   2,
+		Columns:    []*Column{newColumn(FieldTypeInteger, 2)},
+	}
+	c.Columns[0].append("5")  // valid
+	c.Columns[0].append("NA") // fails to parse, so Valid[1] == false
+
+	var first row
+	if err := c.DecodeRow(0, &first); err != nil {
+		t.Fatalf("DecodeRow(0): %v", err)
+	}
+	if first.Units == nil || *first.Units != 5 {
+		t.Errorf("row 0 Units = %v, want pointer to 5", first.Units)
+	}
+
+	var second row
+	if err := c.DecodeRow(1, &second); err != nil {
+		t.Fatalf("DecodeRow(1): %v", err)
+	}
+	if second.Units != nil {
+		t.Errorf("row 1 Units = %v, want nil for an invalid cell", *second.Units)
+	}
+}
+
+func TestDecodeRowOutOfRange(t *testing.T) {
+	c := New()
+	if err := c.ImportReader(strings.NewReader(unmarshalCSV), DefaultImportOptions()); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	var row salesRow
+	if err := c.DecodeRow(99, &row); err == nil {
+		t.Fatalf("expected an out-of-range error")
+	}
+}
+
+func TestUnmarshalRequiresPointerToSliceOfStructs(t *testing.T) {
+	c := New()
+	if err := c.ImportReader(strings.NewReader(unmarshalCSV), DefaultImportOptions()); err != nil {
+		t.Fatalf("ImportReader: %v", err)
+	}
+
+	var notAPointer []salesRow
+	if err := c.Unmarshal(notAPointer); err == nil {
+		t.Fatalf("expected an error when dst isn't a pointer")
+	}
+
+	var wrongElem []int
+	if err := c.Unmarshal(&wrongElem); err == nil {
+		t.Fatalf("expected an error when the slice element isn't a struct")
+	}
+}
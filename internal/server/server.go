@@ -0,0 +1,69 @@
+// Package server exposes a DataSetCache over HTTP using Echo.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/matjam/flatland/internal/cache"
+)
+
+// New builds an Echo server backed by an already-imported DataSetCache.
+func New(c *cache.DataSetCache) *echo.Echo {
+	e := echo.New()
+	e.GET("/query", queryHandler(c))
+
+	return e
+}
+
+// queryHandler runs the "sql" query parameter through cache.QueryStream and
+// streams the matching rows back as newline-delimited JSON objects as they
+// are produced, so a caller doesn't have to wait for (or buffer) a
+// multi-million-row response - and the server never materializes the
+// whole result set in memory either.
+func queryHandler(c *cache.DataSetCache) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		sql := ctx.QueryParam("sql")
+		if sql == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, `missing required "sql" query parameter`)
+		}
+
+		resp := ctx.Response()
+		var columns []string
+		var enc *json.Encoder
+
+		err := c.QueryStream(sql,
+			func(cols []string) error {
+				columns = cols
+				resp.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+				resp.WriteHeader(http.StatusOK)
+				enc = json.NewEncoder(resp)
+				return nil
+			},
+			func(row []string) error {
+				record := make(map[string]string, len(columns))
+				for i, col := range columns {
+					record[col] = row[i]
+				}
+
+				if err := enc.Encode(record); err != nil {
+					return err
+				}
+				resp.Flush()
+				return nil
+			},
+		)
+		if err != nil {
+			if columns == nil {
+				// Nothing was written yet - report the error as a normal
+				// HTTP error response instead of a broken ndjson stream.
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return err
+		}
+
+		return nil
+	}
+}